@@ -0,0 +1,351 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var composeNotesCmd = &cobra.Command{
+	Use:   "compose-notes",
+	Short: "Composes human-readable release notes from git history",
+	Long: `Composes human-readable release notes from git history.
+
+Walks the merge commits between --from and --to, classifies each one
+using a marker prefix in its PR title, and prints the result grouped
+under stable section headings. This complements update-releases-file,
+which only maintains the machine-readable YAML consumed by tests.`,
+	RunE: composeNotes,
+}
+
+func init() {
+	composeNotesCmd.Flags().String("from", "", "ref to start the log from (exclusive)")
+	composeNotesCmd.Flags().String("to", "", "ref to end the log at (inclusive); not used when --branch is set")
+	composeNotesCmd.Flags().String("branch", "", "branch whose tip ends the log, in place of --to")
+	composeNotesCmd.Flags().String("format", "markdown", "output format: markdown or json")
+	composeNotesCmd.Flags().String("repo", "cockroachdb/cockroach", "owner/name used to build PR links")
+	composeNotesCmd.Flags().String("area-filter", "", "only include commits whose PR mentions area/<x>")
+	_ = composeNotesCmd.MarkFlagRequired("from")
+	_ = composeNotesCmd.MarkFlagRequired("to")
+}
+
+// noteCategory identifies one of the fixed release-note sections. The
+// zero value is categoryBreaking.
+type noteCategory int
+
+const (
+	categoryBreaking noteCategory = iota
+	categoryFeature
+	categoryFix
+	categoryPerf
+	categoryDocs
+	categoryChore
+	categoryUncategorized
+)
+
+// categoryOrder lists the sections in the order they should appear in
+// the composed notes.
+var categoryOrder = []noteCategory{
+	categoryBreaking,
+	categoryFeature,
+	categoryFix,
+	categoryPerf,
+	categoryDocs,
+	categoryChore,
+	categoryUncategorized,
+}
+
+var categoryHeadings = map[noteCategory]string{
+	categoryBreaking:      "Breaking Changes",
+	categoryFeature:       "Features",
+	categoryFix:           "Bug Fixes",
+	categoryPerf:          "Performance Improvements",
+	categoryDocs:          "Documentation",
+	categoryChore:         "Other",
+	categoryUncategorized: "Uncategorized",
+}
+
+// categoryMarkers maps the recognized prefix markers to the category
+// they classify a PR title into. Matching is case-insensitive and
+// markers are tried in the order below, so more specific markers
+// (emoji) are listed alongside their plain-text equivalent.
+var categoryMarkers = []struct {
+	marker   string
+	category noteCategory
+}{
+	{"⚠", categoryBreaking},
+	{"!", categoryBreaking},
+	{"✨", categoryFeature},
+	{"feat", categoryFeature},
+	{"🐛", categoryFix},
+	{"fix", categoryFix},
+	{"🏃", categoryPerf},
+	{"perf", categoryPerf},
+	{"📖", categoryDocs},
+	{"docs", categoryDocs},
+	{"🌱", categoryChore},
+	{"chore", categoryChore},
+}
+
+// mergeCommit is a single qualifying commit from the git log, already
+// stripped down to the fields compose-notes cares about.
+type mergeCommit struct {
+	PRNumber int
+	Title    string
+	Category noteCategory
+}
+
+// prSquashPattern and prMergePattern extract the PR number and title
+// from merge commit subjects produced by either a PR-squash merge
+// ("some title (#1234)") or a linear-history merge
+// ("Merge pull request #1234 from ...").
+var (
+	prSquashPattern = regexp.MustCompile(`^(.*)\(#(\d+)\)\s*$`)
+	prMergePattern  = regexp.MustCompile(`^Merge pull request #(\d+) from \S+$`)
+)
+
+func composeNotes(cmd *cobra.Command, _ []string) error {
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	branch, _ := cmd.Flags().GetString("branch")
+	format, _ := cmd.Flags().GetString("format")
+	repo, _ := cmd.Flags().GetString("repo")
+	areaFilter, _ := cmd.Flags().GetString("area-filter")
+
+	if format != "markdown" && format != "json" {
+		return fmt.Errorf("invalid --format %q: must be markdown or json", format)
+	}
+
+	records, err := gitLogRecords(from, to, branch)
+	if err != nil {
+		return err
+	}
+
+	commits, err := classifyCommits(records, areaFilter)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		return printNotesJSON(cmd, commits)
+	}
+	printNotesMarkdown(cmd, commits, repo)
+	return nil
+}
+
+// commitRecord is one commit's subject and body, as produced by
+// gitLogRecords.
+type commitRecord struct {
+	Subject string
+	Body    string
+}
+
+// recordSeparator delimits one commit's "%s%n%b" from the next in the
+// raw `git log` output, so a multi-line body doesn't get confused with
+// the next commit's subject.
+const recordSeparator = "\x00"
+
+// gitLogRecords runs `git log` between `from` and `to` and returns the
+// subject and body of every commit, oldest first. Both linear-history
+// (`--first-parent`) and PR-squash repos produce records this package
+// can parse; the caller is responsible for telling the two apart. The
+// body is needed because, for a linear-history merge commit, GitHub
+// puts the PR's actual title there — the subject itself is just
+// "Merge pull request #N from owner/branch". If branch is set, the log
+// runs from `from` to the tip of branch and `to` is not used;
+// otherwise it runs from `from` to `to`.
+var gitLogRecords = func(from, to, branch string) ([]commitRecord, error) {
+	upTo := to
+	if branch != "" {
+		upTo = branch
+	}
+	args := []string{"log", "--first-parent", "--pretty=format:%s%n%b" + recordSeparator, fmt.Sprintf("%s..%s", from, upTo)}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log %s..%s failed: %w", from, upTo, err)
+	}
+
+	raw := strings.TrimSuffix(strings.TrimSpace(string(out)), recordSeparator)
+	if raw == "" {
+		return nil, nil
+	}
+
+	chunks := strings.Split(raw, recordSeparator)
+	records := make([]commitRecord, len(chunks))
+	for i, c := range chunks {
+		c = strings.Trim(c, "\n")
+		parts := strings.SplitN(c, "\n", 2)
+		record := commitRecord{Subject: parts[0]}
+		if len(parts) > 1 {
+			record.Body = parts[1]
+		}
+		records[i] = record
+	}
+
+	// `git log` prints newest first; release notes read better oldest
+	// first.
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, nil
+}
+
+// classifyCommits parses each record into a mergeCommit, skipping
+// anything that does not look like a merge commit and dropping
+// duplicate PRs (keeping the first occurrence).
+func classifyCommits(records []commitRecord, areaFilter string) ([]mergeCommit, error) {
+	seen := map[int]bool{}
+	var commits []mergeCommit
+	for _, r := range records {
+		prNumber, title, ok := parseMergeCommit(r.Subject, r.Body)
+		if !ok {
+			continue
+		}
+		if seen[prNumber] {
+			continue
+		}
+		if areaFilter != "" && !strings.Contains(title, "area/"+areaFilter) {
+			continue
+		}
+		seen[prNumber] = true
+		commits = append(commits, mergeCommit{
+			PRNumber: prNumber,
+			Title:    strings.TrimSpace(title),
+			Category: classifyTitle(title),
+		})
+	}
+	return commits, nil
+}
+
+// parseMergeCommit extracts the PR number and a classifiable title
+// from a commit's subject and body, handling both the "Merge pull
+// request #N from ..." form (linear-history repos) and the
+// "title (#N)" form left behind by GitHub's squash-and-merge. It
+// returns ok=false for commits that match neither.
+func parseMergeCommit(subject, body string) (prNumber int, title string, ok bool) {
+	if m := prSquashPattern.FindStringSubmatch(subject); m != nil {
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return 0, "", false
+		}
+		return n, m[1], true
+	}
+	if m := prMergePattern.FindStringSubmatch(subject); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, "", false
+		}
+		// GitHub's merge-commit message carries the PR's actual title
+		// as the first non-empty line of the body; fall back to the
+		// merge subject itself if the body is empty for some reason.
+		title := firstNonEmptyLine(body)
+		if title == "" {
+			title = subject
+		}
+		return n, title, true
+	}
+	return 0, "", false
+}
+
+// firstNonEmptyLine returns the first line of body that isn't blank
+// after trimming whitespace, or "" if every line is blank.
+func firstNonEmptyLine(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// classifyTitle returns the category a PR title belongs to, checking
+// markers case-insensitively and falling back to categoryUncategorized.
+func classifyTitle(title string) noteCategory {
+	lower := strings.ToLower(title)
+	for _, m := range categoryMarkers {
+		if strings.HasPrefix(lower, strings.ToLower(m.marker)) {
+			return m.category
+		}
+	}
+	return categoryUncategorized
+}
+
+func printNotesMarkdown(cmd *cobra.Command, commits []mergeCommit, repo string) {
+	byCategory := map[noteCategory][]mergeCommit{}
+	for _, c := range commits {
+		byCategory[c.Category] = append(byCategory[c.Category], c)
+	}
+
+	for _, category := range categoryOrder {
+		entries := byCategory[category]
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "## %s\n\n", categoryHeadings[category])
+		for _, c := range entries {
+			fmt.Fprintf(cmd.OutOrStdout(), "- %s ([#%d](https://github.com/%s/pull/%d))\n",
+				stripMarker(c.Title), c.PRNumber, repo, c.PRNumber)
+		}
+		fmt.Fprintln(cmd.OutOrStdout())
+	}
+}
+
+func printNotesJSON(cmd *cobra.Command, commits []mergeCommit) error {
+	type jsonEntry struct {
+		Category string `json:"category"`
+		PRNumber int    `json:"pr_number"`
+		Title    string `json:"title"`
+	}
+	entries := make([]jsonEntry, len(commits))
+	for i, c := range commits {
+		entries[i] = jsonEntry{
+			Category: categoryHeadings[c.Category],
+			PRNumber: c.PRNumber,
+			Title:    stripMarker(c.Title),
+		}
+	}
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// stripMarker removes leading classification markers (and any
+// separating punctuation or whitespace) from a PR title so it reads
+// naturally in the composed notes. Titles sometimes carry both an
+// emoji and its plain-text equivalent (e.g. "✨ feat: ..."), so this
+// strips repeatedly until no marker matches.
+func stripMarker(title string) string {
+	for {
+		lower := strings.ToLower(title)
+		stripped := false
+		for _, m := range categoryMarkers {
+			prefix := strings.ToLower(m.marker)
+			if strings.HasPrefix(lower, prefix) {
+				rest := title[len(prefix):]
+				title = strings.TrimSpace(strings.TrimLeft(rest, ": -"))
+				stripped = true
+				break
+			}
+		}
+		if !stripped {
+			break
+		}
+	}
+	return title
+}
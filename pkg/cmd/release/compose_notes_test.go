@@ -0,0 +1,176 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func record(subject, body string) commitRecord {
+	return commitRecord{Subject: subject, Body: body}
+}
+
+// squashFixture simulates a PR-squash repo, where `git log --pretty=%s`
+// yields one subject per PR with the PR number trailing in
+// parentheses, and no body.
+var squashFixture = []commitRecord{
+	record("fix: correct off-by-one in range split (#100)", ""),
+	record("✨ feat: add compose-notes subcommand (#101)", ""),
+	record("some internal cleanup (#102)", ""),
+	record("⚠ remove deprecated --legacy flag (#103)", ""),
+	record("fix: correct off-by-one in range split (#100)", ""), // duplicate merge, e.g. cherry-pick
+}
+
+// mergeFixture simulates a linear-history repo, where merge commits
+// carry the canonical "Merge pull request #N from ..." subject and
+// GitHub writes the PR's actual title as the body.
+var mergeFixture = []commitRecord{
+	record("Merge pull request #200 from someuser/fix-branch", "fix: correct a race in the liveness range\n"),
+	record("Merge pull request #201 from someuser/feat-branch", "✨ feat: add compose-notes subcommand\n"),
+}
+
+func TestClassifyCommits(t *testing.T) {
+	commits, err := classifyCommits(squashFixture, "")
+	require.NoError(t, err)
+	require.Len(t, commits, 4, "duplicate PR #100 should be deduped")
+
+	require.Equal(t, 100, commits[0].PRNumber)
+	require.Equal(t, categoryFix, commits[0].Category)
+
+	require.Equal(t, 101, commits[1].PRNumber)
+	require.Equal(t, categoryFeature, commits[1].Category)
+
+	require.Equal(t, 102, commits[2].PRNumber)
+	require.Equal(t, categoryUncategorized, commits[2].Category)
+
+	require.Equal(t, 103, commits[3].PRNumber)
+	require.Equal(t, categoryBreaking, commits[3].Category)
+}
+
+func TestClassifyCommits_LinearHistory(t *testing.T) {
+	commits, err := classifyCommits(mergeFixture, "")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+
+	require.Equal(t, 200, commits[0].PRNumber)
+	require.Equal(t, categoryFix, commits[0].Category)
+
+	require.Equal(t, 201, commits[1].PRNumber)
+	require.Equal(t, categoryFeature, commits[1].Category)
+}
+
+func TestClassifyCommits_AreaFilter(t *testing.T) {
+	records := []commitRecord{
+		record("fix: storage bug (area/storage) (#300)", ""),
+		record("fix: sql bug (area/sql) (#301)", ""),
+	}
+
+	commits, err := classifyCommits(records, "storage")
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	require.Equal(t, 300, commits[0].PRNumber)
+}
+
+func TestParseMergeCommit(t *testing.T) {
+	prNumber, title, ok := parseMergeCommit(mergeFixture[0].Subject, mergeFixture[0].Body)
+	require.True(t, ok)
+	require.Equal(t, 200, prNumber)
+	require.Equal(t, "fix: correct a race in the liveness range", title)
+
+	// A merge commit with no body at all falls back to the subject,
+	// which at least preserves the PR number even if it can't be
+	// classified.
+	prNumber, title, ok = parseMergeCommit("Merge pull request #202 from someuser/branch", "")
+	require.True(t, ok)
+	require.Equal(t, 202, prNumber)
+	require.Equal(t, "Merge pull request #202 from someuser/branch", title)
+
+	_, _, ok = parseMergeCommit("not a merge commit at all", "")
+	require.False(t, ok)
+}
+
+func TestFirstNonEmptyLine(t *testing.T) {
+	require.Equal(t, "title", firstNonEmptyLine("\n\n  title  \nmore body text\n"))
+	require.Equal(t, "", firstNonEmptyLine("\n\n  \n"))
+}
+
+func TestStripMarker(t *testing.T) {
+	require.Equal(t, "add compose-notes subcommand", stripMarker("✨ feat: add compose-notes subcommand"))
+	require.Equal(t, "correct off-by-one", stripMarker("fix: correct off-by-one"))
+	require.Equal(t, "plain title", stripMarker("plain title"))
+}
+
+// runGit runs git in dir, failing the test on error.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoErrorf(t, err, "git %v: %s", args, out)
+}
+
+func commitFile(t *testing.T, dir, name, subject string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-m", subject)
+}
+
+func revParse(t *testing.T, dir, ref string) string {
+	t.Helper()
+	cmd := exec.Command("git", "rev-parse", ref)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	return strings.TrimSpace(string(out))
+}
+
+// TestGitLogRecords_BranchScope builds a scratch repo with a release
+// branch holding the commits under test, plus an unrelated third
+// branch, and checks that gitLogRecords with --branch set returns
+// only commits in from..branch, not commits pulled in from the
+// unrelated branch.
+func TestGitLogRecords_BranchScope(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "checkout", "-q", "-b", "main")
+
+	commitFile(t, dir, "base.txt", "base commit")
+	fromSHA := revParse(t, dir, "HEAD")
+
+	runGit(t, dir, "checkout", "-q", "-b", "release-branch")
+	commitFile(t, dir, "release.txt", "in-range commit")
+	toSHA := revParse(t, dir, "HEAD")
+
+	runGit(t, dir, "checkout", "-q", "main")
+	runGit(t, dir, "checkout", "-q", "-b", "unrelated-branch")
+	commitFile(t, dir, "unrelated.txt", "unrelated commit")
+
+	origWD, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(origWD)) }()
+
+	records, err := gitLogRecords(fromSHA, toSHA, "release-branch")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "in-range commit", records[0].Subject)
+}
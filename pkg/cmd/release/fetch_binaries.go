@@ -0,0 +1,141 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils/release/store"
+	"github.com/spf13/cobra"
+)
+
+var defaultPlatforms = []string{"linux/amd64", "linux/arm64", "darwin/amd64", "darwin/arm64"}
+
+var fetchBinariesCmd = &cobra.Command{
+	Use:   "fetch-binaries",
+	Short: "Downloads and caches cockroach binaries for mixed-version testing",
+	Long: `Downloads, verifies, and caches cockroach binaries for every
+non-withdrawn release described by cockroach_releases.yaml, so that
+mixed-version roachtests and logic tests can run offline against a
+curated corpus.`,
+	RunE: fetchBinaries,
+}
+
+var fetchBinariesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the cockroach binaries currently cached locally",
+	RunE:  fetchBinariesList,
+}
+
+var fetchBinariesRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Removes cached cockroach binaries matching the given criteria",
+	RunE:  fetchBinariesRemove,
+}
+
+var fetchBinariesPathCmd = &cobra.Command{
+	Use:   "path <version>",
+	Short: "Prints the local path to a cached cockroach binary, downloading it if necessary",
+	Args:  cobra.ExactArgs(1),
+	RunE:  fetchBinariesPath,
+}
+
+func init() {
+	fetchBinariesCmd.Flags().String("platform", strings.Join(defaultPlatforms, ","), "comma-separated list of platforms to fetch")
+	fetchBinariesCmd.AddCommand(fetchBinariesListCmd)
+	fetchBinariesCmd.AddCommand(fetchBinariesRemoveCmd)
+	fetchBinariesCmd.AddCommand(fetchBinariesPathCmd)
+
+	fetchBinariesListCmd.Flags().String("platform", "linux/amd64", "platform to list cached binaries for")
+
+	fetchBinariesRemoveCmd.Flags().String("older-than", "", "remove every release older than this series (e.g. 23.1)")
+	fetchBinariesRemoveCmd.Flags().Int("keep-latest", 0, "keep only the N most recently cached releases per platform")
+
+	fetchBinariesPathCmd.Flags().String("platform", "linux/amd64", "platform to resolve the binary for")
+}
+
+func fetchBinaries(cmd *cobra.Command, _ []string) error {
+	platformFlag, _ := cmd.Flags().GetString("platform")
+	platforms := store.ParsePlatformList(platformFlag)
+
+	s, err := store.New()
+	if err != nil {
+		return err
+	}
+
+	versions, err := store.LatestVersions()
+	if err != nil {
+		return err
+	}
+
+	for name, version := range versions {
+		for _, platform := range platforms {
+			fmt.Fprintf(cmd.OutOrStdout(), "fetching %s (%s) for %s\n", version, name, platform)
+			if _, err := s.Get(context.Background(), version, platform); err != nil {
+				return fmt.Errorf("fetching %s/%s: %w", version, platform, err)
+			}
+		}
+	}
+	return nil
+}
+
+func fetchBinariesList(cmd *cobra.Command, _ []string) error {
+	platform, _ := cmd.Flags().GetString("platform")
+
+	s, err := store.New()
+	if err != nil {
+		return err
+	}
+	versions, err := s.List(platform)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		fmt.Fprintln(cmd.OutOrStdout(), v)
+	}
+	return nil
+}
+
+func fetchBinariesRemove(cmd *cobra.Command, _ []string) error {
+	olderThan, _ := cmd.Flags().GetString("older-than")
+	keepLatest, _ := cmd.Flags().GetInt("keep-latest")
+
+	if (olderThan == "") == (keepLatest == 0) {
+		return fmt.Errorf("exactly one of --older-than or --keep-latest must be set")
+	}
+
+	s, err := store.New()
+	if err != nil {
+		return err
+	}
+
+	if olderThan != "" {
+		return s.RemoveOlderThan(olderThan)
+	}
+	return s.RemoveKeepLatest(keepLatest)
+}
+
+func fetchBinariesPath(cmd *cobra.Command, args []string) error {
+	platform, _ := cmd.Flags().GetString("platform")
+
+	s, err := store.New()
+	if err != nil {
+		return err
+	}
+	path, err := s.Get(context.Background(), args[0], platform)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), path)
+	return nil
+}
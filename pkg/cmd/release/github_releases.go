@@ -0,0 +1,248 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils/release"
+)
+
+// githubReleasesURL is the paginated GitHub API endpoint listing
+// every published release of the main cockroachdb/cockroach repo.
+const githubReleasesURL = "https://api.github.com/repos/cockroachdb/cockroach/releases"
+
+// githubReleasesPerPage is the page size requested from the GitHub
+// API; 100 is the maximum it allows.
+const githubReleasesPerPage = 100
+
+// githubRelease is the subset of a GitHub release we care about,
+// decoded directly from the API's JSON response.
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Prerelease bool   `json:"prerelease"`
+	Assets     []struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// hasAsset reports whether the release has an attached asset with the
+// given name.
+func (r githubRelease) hasAsset(name string) bool {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchGitHubReleases downloads every release in the
+// cockroachdb/cockroach repo, following pagination until a short page
+// signals the end of the list. If GITHUB_TOKEN is set in the
+// environment, it is sent along so the request counts against the
+// authenticated (much higher) rate limit instead of the anonymous one.
+func fetchGitHubReleases(ctx context.Context) ([]githubRelease, error) {
+	var all []githubRelease
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s?per_page=%d&page=%d", githubReleasesURL, githubReleasesPerPage, page)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", url, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading response from %s: %w", url, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d fetching %s: %s", resp.StatusCode, url, body)
+		}
+
+		var page []githubRelease
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("parsing response from %s: %w", url, err)
+		}
+		all = append(all, page...)
+		if len(page) < githubReleasesPerPage {
+			break
+		}
+	}
+	return all, nil
+}
+
+// driftReport describes every inconsistency found between the docs
+// YAML and the GitHub Releases feed.
+type driftReport struct {
+	// MissingOnGitHub lists docs releases that have no corresponding
+	// GitHub release with the expected binary and checksum assets.
+	MissingOnGitHub []string `json:"missing_on_github,omitempty"`
+	// MissingInDocs lists GitHub release tags, on series we track, that
+	// have no corresponding entry in the docs YAML.
+	MissingInDocs []string `json:"missing_in_docs,omitempty"`
+}
+
+func (r driftReport) empty() bool {
+	return len(r.MissingOnGitHub) == 0 && len(r.MissingInDocs) == 0
+}
+
+func (r driftReport) text() string {
+	var b strings.Builder
+	if len(r.MissingOnGitHub) > 0 {
+		fmt.Fprintf(&b, "releases in docs YAML with no matching GitHub release (tag + binary + checksum):\n")
+		for _, name := range r.MissingOnGitHub {
+			fmt.Fprintf(&b, "  - %s\n", name)
+		}
+	}
+	if len(r.MissingInDocs) > 0 {
+		fmt.Fprintf(&b, "GitHub release tags with no matching docs YAML entry:\n")
+		for _, name := range r.MissingInDocs {
+			fmt.Fprintf(&b, "  - %s\n", name)
+		}
+	}
+	return b.String()
+}
+
+// reconcileWithGitHub compares the releases described by the docs YAML
+// against the GitHub Releases feed, looking for drift in both
+// directions: a docs release with no downloadable GitHub counterpart,
+// and a GitHub release on a tracked series with no docs entry.
+// trackedSeries should be the set of series update-releases-file
+// already decided to keep (i.e. the keys of processReleaseData's
+// result), so that GitHub tags on ancient or out-of-window series don't
+// produce noise. The docs side is checked for every release that is
+// neither withdrawn nor cloud-only, whatever its series.
+//
+// Note this can't exempt cloud-only GitHub tags from the
+// MissingInDocs check, the way the docs YAML's own `cloud_only` field
+// lets the MissingOnGitHub check skip docs releases: there is no
+// signal in the GitHub Releases API response that identifies a tag as
+// cloud-only once it has no docs entry to read that flag from. An
+// undocumented cloud-only release on a tracked series will therefore
+// surface as MissingInDocs; until GitHub exposes such a signal (or we
+// maintain a separate allowlist), that's a false positive reviewers
+// need to know to ignore.
+func reconcileWithGitHub(
+	docs []Release, ghReleases []githubRelease, trackedSeries map[string]bool,
+) driftReport {
+	docsByTag := map[string]bool{}
+	for _, r := range docs {
+		docsByTag["v"+releaseName(r.Name)] = true
+	}
+
+	ghByTag := map[string]githubRelease{}
+	for _, gh := range ghReleases {
+		ghByTag[gh.TagName] = gh
+	}
+
+	var report driftReport
+	for _, r := range docs {
+		if r.Withdrawn || r.CloudOnly {
+			continue
+		}
+		tag := "v" + releaseName(r.Name)
+		gh, ok := ghByTag[tag]
+		wantTarball := fmt.Sprintf("cockroach-%s.linux-amd64.tgz", tag)
+		wantChecksum := wantTarball + ".sha256"
+		if !ok || !gh.hasAsset(wantTarball) || !gh.hasAsset(wantChecksum) {
+			report.MissingOnGitHub = append(report.MissingOnGitHub, r.Name)
+		}
+	}
+
+	for _, gh := range ghReleases {
+		if gh.Prerelease || docsByTag[gh.TagName] {
+			continue
+		}
+		if !trackedSeries[seriesOfTag(gh.TagName)] {
+			continue
+		}
+		report.MissingInDocs = append(report.MissingInDocs, gh.TagName)
+	}
+
+	sort.Strings(report.MissingOnGitHub)
+	sort.Strings(report.MissingInDocs)
+	return report
+}
+
+// seriesOfTag extracts the "X.Y" series from a release tag such as
+// "v23.1.13".
+func seriesOfTag(tag string) string {
+	name := releaseName(tag)
+	parts := strings.SplitN(name, ".", 3)
+	if len(parts) < 2 {
+		return name
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// verifyAgainstGitHub fetches the GitHub Releases feed and reconciles
+// it against the docs YAML, printing a report in the requested format.
+// It returns an error (without printing usage) if drift was found and
+// allowDrift is false, so RunE surfaces a non-zero exit code.
+func verifyAgainstGitHub(
+	ctx context.Context,
+	out io.Writer,
+	docs []Release,
+	trackedSeries map[string]release.Series,
+	format string,
+	allowDrift bool,
+) error {
+	ghReleases, err := fetchGitHubReleases(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching GitHub releases: %w", err)
+	}
+
+	tracked := make(map[string]bool, len(trackedSeries))
+	for name := range trackedSeries {
+		tracked[name] = true
+	}
+
+	report := reconcileWithGitHub(docs, ghReleases, tracked)
+	if report.empty() {
+		fmt.Fprintln(out, "no drift found between docs YAML and GitHub releases")
+		return nil
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	case "text":
+		fmt.Fprint(out, report.text())
+	default:
+		return fmt.Errorf("invalid --report %q: must be json or text", format)
+	}
+
+	if !allowDrift {
+		return fmt.Errorf("docs YAML and GitHub releases have drifted; pass --allow-drift to ignore")
+	}
+	return nil
+}
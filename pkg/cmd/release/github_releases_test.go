@@ -0,0 +1,91 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func releaseWithAssets(tag string, prerelease bool, assetNames ...string) githubRelease {
+	gh := githubRelease{TagName: tag, Prerelease: prerelease}
+	for _, name := range assetNames {
+		gh.Assets = append(gh.Assets, struct {
+			Name               string `json:"name"`
+			BrowserDownloadURL string `json:"browser_download_url"`
+		}{Name: name})
+	}
+	return gh
+}
+
+func TestReconcileWithGitHub_MissingOnGitHub(t *testing.T) {
+	docs := []Release{
+		{Name: "v23.1.13", Series: "23.1"},
+		{Name: "v23.1.14", Series: "23.1"},
+	}
+	gh := []githubRelease{
+		releaseWithAssets("v23.1.13", false, "cockroach-v23.1.13.linux-amd64.tgz", "cockroach-v23.1.13.linux-amd64.tgz.sha256"),
+		// v23.1.14 is missing the checksum asset.
+		releaseWithAssets("v23.1.14", false, "cockroach-v23.1.14.linux-amd64.tgz"),
+	}
+
+	report := reconcileWithGitHub(docs, gh, map[string]bool{"23.1": true})
+	require.Equal(t, []string{"v23.1.14"}, report.MissingOnGitHub)
+	require.Empty(t, report.MissingInDocs)
+}
+
+func TestReconcileWithGitHub_MissingInDocs(t *testing.T) {
+	docs := []Release{
+		{Name: "v23.1.13", Series: "23.1"},
+	}
+	gh := []githubRelease{
+		releaseWithAssets("v23.1.13", false, "cockroach-v23.1.13.linux-amd64.tgz", "cockroach-v23.1.13.linux-amd64.tgz.sha256"),
+		releaseWithAssets("v23.1.14", false, "cockroach-v23.1.14.linux-amd64.tgz", "cockroach-v23.1.14.linux-amd64.tgz.sha256"),
+	}
+
+	report := reconcileWithGitHub(docs, gh, map[string]bool{"23.1": true})
+	require.Empty(t, report.MissingOnGitHub)
+	require.Equal(t, []string{"v23.1.14"}, report.MissingInDocs)
+}
+
+func TestReconcileWithGitHub_IgnoresUntrackedAndDocsCloudOnly(t *testing.T) {
+	docs := []Release{
+		// A docs entry marked cloud_only is exempt from the
+		// MissingOnGitHub check, since its binary is never published.
+		{Name: "v20.2.1", Series: "20.2", CloudOnly: true},
+	}
+	gh := []githubRelease{
+		// Not on a tracked series, and also a prerelease: neither should surface.
+		releaseWithAssets("v19.1.0", true, "cockroach-v19.1.0.linux-amd64.tgz"),
+	}
+
+	report := reconcileWithGitHub(docs, gh, map[string]bool{"23.1": true})
+	require.True(t, report.empty())
+}
+
+// TestReconcileWithGitHub_UndocumentedCloudOnlyIsAFalsePositive documents
+// a known limitation: an undocumented cloud-only GitHub release on a
+// tracked series has no way to identify itself as cloud-only once it
+// has no docs entry, so it surfaces as MissingInDocs even though
+// that's expected. See the reconcileWithGitHub doc comment.
+func TestReconcileWithGitHub_UndocumentedCloudOnlyIsAFalsePositive(t *testing.T) {
+	gh := []githubRelease{
+		releaseWithAssets("v23.1.15", false, "cockroach-v23.1.15.linux-amd64.tgz", "cockroach-v23.1.15.linux-amd64.tgz.sha256"),
+	}
+
+	report := reconcileWithGitHub(nil, gh, map[string]bool{"23.1": true})
+	require.Equal(t, []string{"v23.1.15"}, report.MissingInDocs)
+}
+
+func TestSeriesOfTag(t *testing.T) {
+	require.Equal(t, "23.1", seriesOfTag("v23.1.13"))
+}
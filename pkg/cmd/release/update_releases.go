@@ -21,6 +21,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/build"
 	"github.com/cockroachdb/cockroach/pkg/testutils/release"
+	"github.com/cockroachdb/cockroach/pkg/testutils/release/store"
 	"github.com/cockroachdb/cockroach/pkg/util/httputil"
 	"github.com/cockroachdb/cockroach/pkg/util/version"
 	"github.com/spf13/cobra"
@@ -34,6 +35,13 @@ var updateReleasesTestFileCmd = &cobra.Command{
 	RunE:  updateReleasesFile,
 }
 
+func init() {
+	updateReleasesTestFileCmd.Flags().Bool("prewarm-cache", false, "download binaries for the latest release of every new or updated release series into the local cache")
+	updateReleasesTestFileCmd.Flags().String("prewarm-platform", "linux/amd64", "comma-separated list of platforms to pre-warm the cache for")
+	updateReleasesTestFileCmd.Flags().String("report", "text", "format of the GitHub drift report: json or text")
+	updateReleasesTestFileCmd.Flags().Bool("allow-drift", false, "don't fail if the docs YAML and GitHub releases have drifted")
+}
+
 // minVersion corresponds to the minimum version after which we start
 // keeping release data for testing purposes.
 var minVersion = version.MustParse("v21.2.0")
@@ -61,13 +69,29 @@ type Release struct {
 	Previous  string `yaml:"previous_release"`
 	Withdrawn bool   `yaml:"withdrawn"`
 	CloudOnly bool   `yaml:"cloud_only"`
+	// VersionFormat names the release.Format (see
+	// release.RegisterFormat) that Name should be parsed and compared
+	// with. Empty means release.DefaultFormat, which is how every
+	// release in the docs YAML has been named historically.
+	VersionFormat string `yaml:"version_format"`
+}
+
+// format returns the release.Format that should be used to parse and
+// compare r.Name, falling back to release.DefaultFormat.
+func (r Release) format() (name string, format release.Format, ok bool) {
+	name = r.VersionFormat
+	if name == "" {
+		name = release.DefaultFormat
+	}
+	format, ok = release.GetFormat(name)
+	return name, format, ok
 }
 
 // updateReleasesFile downloads the current release data from the docs
 // repo and generates the corresponding data expected by the `release`
 // package, saving the final result in the `cockroach_releases.yaml`
 // file which is then embedded into the binary.
-func updateReleasesFile(_ *cobra.Command, _ []string) (retErr error) {
+func updateReleasesFile(cmd *cobra.Command, _ []string) (retErr error) {
 	fmt.Printf("downloading release data from %q\n", releaseDataURL)
 	data, err := downloadReleases()
 	if err != nil {
@@ -75,9 +99,18 @@ func updateReleasesFile(_ *cobra.Command, _ []string) (retErr error) {
 	}
 	fmt.Printf("downloaded release data for %d releases\n", len(data))
 
+	previous, _ := release.AllSeries()
+
 	result := processReleaseData(data)
 	fmt.Printf("generated data for %d release series\n", len(result))
 
+	reportFormat, _ := cmd.Flags().GetString("report")
+	allowDrift, _ := cmd.Flags().GetBool("allow-drift")
+	fmt.Printf("checking for drift against GitHub releases\n")
+	if err := verifyAgainstGitHub(context.Background(), cmd.OutOrStdout(), data, result, reportFormat, allowDrift); err != nil {
+		return err
+	}
+
 	if err := validateReleaseData(result); err != nil {
 		return fmt.Errorf("failed to validate downloaded data: %w", err)
 	}
@@ -88,29 +121,86 @@ func updateReleasesFile(_ *cobra.Command, _ []string) (retErr error) {
 		return err
 	}
 	fmt.Printf("done\n")
+
+	if prewarm, _ := cmd.Flags().GetBool("prewarm-cache"); prewarm {
+		platformFlag, _ := cmd.Flags().GetString("prewarm-platform")
+		if err := prewarmNewSeries(previous, result, strings.Split(platformFlag, ",")); err != nil {
+			return fmt.Errorf("pre-warming cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// prewarmNewSeries downloads binaries for the latest release of every
+// series in `current` whose latest release is not the one recorded in
+// `previous` (including series absent from `previous`), so that the
+// cache is ready for use as soon as a release becomes testable.
+func prewarmNewSeries(previous, current map[string]release.Series, platforms []string) error {
+	s, err := store.New()
+	if err != nil {
+		return err
+	}
+
+	for name, series := range current {
+		if series.Latest == "" {
+			continue
+		}
+		if old, ok := previous[name]; ok && old.Latest == series.Latest {
+			continue
+		}
+		fmt.Printf("pre-warming cache for newly added release %s (%s)\n", series.Latest, name)
+		for _, platform := range platforms {
+			if _, err := s.Get(context.Background(), series.Latest, platform); err != nil {
+				return fmt.Errorf("fetching %s/%s: %w", series.Latest, platform, err)
+			}
+		}
+	}
 	return nil
 }
 
 func processReleaseData(data []Release) map[string]release.Series {
 	var filtered []Release
 	for _, r := range data {
+		formatName, format, ok := r.format()
+		if !ok {
+			// Unknown format: we have no way to validate or order this
+			// release, so skip it rather than risk corrupting the
+			// corpus with data we can't reason about.
+			continue
+		}
+
+		// Docs release names carry a leading "v", which formats don't
+		// expect.
+		name := releaseName(r.Name)
+
 		// We ignore versions that cannot be parsed; this should
 		// correspond to really old beta releases.
-		v, err := version.Parse(r.Name)
-		if err != nil {
+		if err := format.Parse(name); err != nil {
 			continue
 		}
 
-		// Filter out everything that is older than `minVersion`
-		if !v.AtLeast(minVersion) {
-			continue
+		// minVersion is itself a semver-v version, so the floor only
+		// applies to releases using that format; every other format is
+		// only ever used by test-authored fixtures, not the docs feed.
+		if formatName == release.DefaultFormat {
+			if !version.MustParse("v" + name).AtLeast(minVersion) {
+				continue
+			}
 		}
 
 		// For the purposes of the cockroach_releases file, we are only
 		// interested in beta and rc pre-releases, as we do not support
-		// upgrades from alpha releases.
-		if pre := v.PreRelease(); pre != "" && !strings.HasPrefix(pre, "rc") && !strings.HasPrefix(pre, "beta") {
-			continue
+		// upgrades from alpha releases. This distinction is specific to
+		// semver-v's pre-release suffixes; other formats don't have an
+		// equivalent, so any pre-release build of theirs is excluded.
+		if format.IsPreRelease(name) {
+			if formatName != release.DefaultFormat {
+				continue
+			}
+			pre := version.MustParse("v" + name).PreRelease()
+			if !strings.HasPrefix(pre, "rc") && !strings.HasPrefix(pre, "beta") {
+				continue
+			}
 		}
 		// Skip cloud-only releases, because the binaries are not yet publicly available.
 		if r.CloudOnly {
@@ -122,9 +212,7 @@ func processReleaseData(data []Release) map[string]release.Series {
 
 	// Sort release information from oldest to newest.
 	sort.Slice(filtered, func(i, j int) bool {
-		vi := version.MustParse(filtered[i].Name)
-		vj := version.MustParse(filtered[j].Name)
-		return vi.Compare(vj) < 0
+		return compareReleases(filtered[i], filtered[j]) < 0
 	})
 
 	bySeries := map[string][]Release{}
@@ -149,21 +237,47 @@ func processReleaseData(data []Release) map[string]release.Series {
 			}
 		}
 
+		formatName, _, _ := releases[0].format()
+		var versionFormat string
+		if formatName != release.DefaultFormat {
+			versionFormat = formatName
+		}
+
 		result[releaseName(seriesName)] = release.Series{
-			Latest:      releaseName(releases[len(releases)-1].Name),
-			Withdrawn:   withdrawn,
-			Predecessor: releaseName(previousMap[seriesName]),
+			Latest:        releaseName(releases[len(releases)-1].Name),
+			Withdrawn:     withdrawn,
+			Predecessor:   releaseName(previousMap[seriesName]),
+			VersionFormat: versionFormat,
 		}
 	}
 
 	return result
 }
 
+// compareReleases orders two releases by version, oldest first. It
+// delegates to the format named by each release, since formats are
+// only guaranteed to produce a meaningful ordering between versions of
+// the same scheme; releases that (unusually) name different formats
+// fall back to a lexical comparison, which is good enough to establish
+// a stable order in the rare case a series changes naming schemes.
+func compareReleases(a, b Release) int {
+	aName, aFormat, aOK := a.format()
+	bName, _, bOK := b.format()
+	if aOK && bOK && aName == bName {
+		return aFormat.Compare(releaseName(a.Name), releaseName(b.Name))
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
 // addCurrentRelease adds an entry to the `data` map corresponding to
 // the binary version of the current build, if one does not exist. The
 // new entry will have no `Latest` information as, in that case, the
 // current release series is still in development.
 func addCurrentRelease(data map[string]release.Series) {
+	// The binary running this command is always built from a
+	// semver-v-named tag, regardless of what format any individual
+	// series in `data` uses, so finding its own series name doesn't go
+	// through the registry.
 	currentVersion := version.MustParse(build.BinaryVersion())
 	name := fmt.Sprintf("%d.%d", currentVersion.Major(), currentVersion.Minor())
 	if _, ok := data[name]; ok {
@@ -172,20 +286,28 @@ func addCurrentRelease(data map[string]release.Series) {
 
 	var latestVersion *version.Version
 	for _, d := range data {
-		v := version.MustParse("v" + d.Latest)
-		if latestVersion == nil {
-			latestVersion = v
+		// Only series sharing the current build's format can be
+		// meaningfully compared against it to find "the latest released
+		// series".
+		if d.Format() != release.MustGetFormat(release.DefaultFormat) {
+			continue
 		}
-
-		if v.AtLeast(latestVersion) {
+		v := version.MustParse("v" + d.Latest)
+		if latestVersion == nil || v.AtLeast(latestVersion) {
 			latestVersion = v
 		}
 	}
 
 	// Assume that the predecessor of the current version is the latest
-	// released series.
+	// released series. If no series shares the current build's format
+	// (e.g. the corpus only has non-default-format fixtures), there is
+	// no predecessor we can name.
+	var predecessor string
+	if latestVersion != nil {
+		predecessor = fmt.Sprintf("%d.%d", latestVersion.Major(), latestVersion.Minor())
+	}
 	data[name] = release.Series{
-		Predecessor: fmt.Sprintf("%d.%d", latestVersion.Major(), latestVersion.Minor()),
+		Predecessor: predecessor,
 	}
 }
 
@@ -193,13 +315,10 @@ func addCurrentRelease(data map[string]release.Series) {
 // data passed to make sure that we are saving consistent data that
 // the `release` package can use.
 func validateReleaseData(data map[string]release.Series) error {
-	tryParseVersion := func(v string) error {
-		_, err := version.Parse("v" + v)
-		return err
-	}
-
 	var noPredecessors string
 	for name, d := range data {
+		format := d.Format()
+
 		if d.Predecessor == "" {
 			if noPredecessors != "" {
 				return fmt.Errorf("two release series without known predecessors: %q and %q", name, noPredecessors)
@@ -217,19 +336,25 @@ func validateReleaseData(data map[string]release.Series) error {
 			return fmt.Errorf("release information for series %q is missing the latest release", name)
 		}
 
-		if err := tryParseVersion(d.Latest); err != nil {
+		if err := format.Parse(d.Latest); err != nil {
 			return fmt.Errorf("release information for series %q has invalid latest release %q: %w", name, d.Latest, err)
 		}
 
 		for _, w := range d.Withdrawn {
-			if err := tryParseVersion(w); err != nil {
+			if err := format.Parse(w); err != nil {
 				return fmt.Errorf("release information for series %q has invalid withdrawn release %q: %w", name, w, err)
 			}
 		}
 
-		numReleases := version.MustParse("v"+d.Latest).Patch() + 1
-		if len(d.Withdrawn) == numReleases {
-			return fmt.Errorf("series %q is invalid: every release has been withdrawn", name)
+		// The "every release has been withdrawn" sanity check relies on
+		// the patch number doubling as a count of releases in the
+		// series, which is only true for semver-v; other formats skip
+		// it.
+		if d.VersionFormat == "" {
+			numReleases := version.MustParse("v"+d.Latest).Patch() + 1
+			if len(d.Withdrawn) == numReleases {
+				return fmt.Errorf("series %q is invalid: every release has been withdrawn", name)
+			}
 		}
 	}
 
@@ -0,0 +1,54 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils/release"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessReleaseData_NonDefaultFormat(t *testing.T) {
+	data := []Release{
+		{Name: "2023.10.1", Series: "cloud", VersionFormat: "calver"},
+		{Name: "2023.10.2", Series: "cloud", VersionFormat: "calver"},
+	}
+
+	result := processReleaseData(data)
+	require.Contains(t, result, "cloud")
+	require.Equal(t, "2023.10.2", result["cloud"].Latest)
+	require.Equal(t, "calver", result["cloud"].VersionFormat)
+}
+
+// TestAddCurrentRelease_NoDefaultFormatSeries covers the case where
+// every series known so far uses a non-default format, so there is no
+// series addCurrentRelease can meaningfully compare the current
+// build's (always semver-v) version against to find "the latest
+// released series".
+func TestAddCurrentRelease_NoDefaultFormatSeries(t *testing.T) {
+	data := map[string]release.Series{
+		"cloud": {Latest: "2023.10.2", VersionFormat: "calver"},
+	}
+
+	require.NotPanics(t, func() { addCurrentRelease(data) })
+
+	var added release.Series
+	var addedOK bool
+	for name, s := range data {
+		if name == "cloud" {
+			continue
+		}
+		added, addedOK = s, true
+	}
+	require.True(t, addedOK, "addCurrentRelease should add an entry for the current build's series")
+	require.Empty(t, added.Predecessor)
+}
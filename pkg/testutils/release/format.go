@@ -0,0 +1,71 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package release
+
+import "fmt"
+
+// Format knows how to parse and order the version strings used by one
+// particular release naming scheme (e.g. CockroachDB's own
+// "vYY.R.patch" releases, or a fork's calendar-versioned builds).
+// Registering a Format lets test authors work with a wider corpus of
+// release data than the single hard-coded scheme this package used to
+// assume, without teaching the rest of the codebase a second parser.
+type Format interface {
+	// Parse reports whether s is a valid version string for this
+	// format, returning a descriptive error if not.
+	Parse(s string) error
+	// Compare returns a negative number if a sorts before b, zero if
+	// they are equal, and a positive number if a sorts after b. Compare
+	// is only meaningful for two strings of the same format.
+	Compare(a, b string) int
+	// IsPreRelease reports whether s names a pre-release build (e.g. an
+	// alpha, beta, or rc) that should not be treated as a series'
+	// latest stable release.
+	IsPreRelease(s string) bool
+}
+
+// DefaultFormat is the name of the format used when a series does not
+// specify one, preserving the behavior this package had before
+// version formats became pluggable.
+const DefaultFormat = "semver-v"
+
+// formats holds every Format registered via RegisterFormat, keyed by
+// name.
+var formats = map[string]Format{}
+
+// RegisterFormat makes a version Format available under name, for use
+// in a series' `version_format` field. It is normally called from an
+// init function. RegisterFormat panics if name is already registered,
+// since that would silently change the meaning of existing data.
+func RegisterFormat(name string, f Format) {
+	if _, exists := formats[name]; exists {
+		panic(fmt.Sprintf("release: version format %q already registered", name))
+	}
+	formats[name] = f
+}
+
+// GetFormat returns the Format registered under name, or false if no
+// such format is known.
+func GetFormat(name string) (Format, bool) {
+	f, ok := formats[name]
+	return f, ok
+}
+
+// MustGetFormat is like GetFormat but panics if name is not
+// registered. It is meant for code paths where the name has already
+// been validated (e.g. data loaded through the docs-YAML loader).
+func MustGetFormat(name string) Format {
+	f, ok := GetFormat(name)
+	if !ok {
+		panic(fmt.Sprintf("release: unknown version format %q", name))
+	}
+	return f
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package release
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemverFormat(t *testing.T) {
+	f := MustGetFormat(DefaultFormat)
+	require.NoError(t, f.Parse("23.1.13"))
+	require.Error(t, f.Parse("not-a-version"))
+	require.True(t, f.Compare("23.1.13", "23.1.2") > 0)
+	require.True(t, f.IsPreRelease("23.1.0-rc.1"))
+	require.False(t, f.IsPreRelease("23.1.0"))
+}
+
+func TestCalverFormat(t *testing.T) {
+	f := MustGetFormat("calver")
+	require.NoError(t, f.Parse("2023.10.1"))
+	require.Error(t, f.Parse("23.1"))
+	require.True(t, f.Compare("2023.10.2", "2023.10.1") > 0)
+	require.False(t, f.IsPreRelease("2023.10.1"))
+}
+
+func TestDevSemiSemanticFormat(t *testing.T) {
+	f := MustGetFormat("dev-semi-semantic")
+	require.NoError(t, f.Parse("23.1.0+build.5"))
+	require.Error(t, f.Parse("23.1.0"))
+	require.True(t, f.Compare("23.1.0+build.6", "23.1.0+build.5") > 0)
+	require.True(t, f.IsPreRelease("23.1.0+dirty.1"))
+	require.False(t, f.IsPreRelease("23.1.0+build.1"))
+}
+
+func TestRegisterFormatPanicsOnDuplicate(t *testing.T) {
+	require.Panics(t, func() {
+		RegisterFormat(DefaultFormat, semverFormat{})
+	})
+}
+
+func TestSeriesFormat(t *testing.T) {
+	require.Equal(t, MustGetFormat(DefaultFormat), Series{}.Format())
+	require.Equal(t, MustGetFormat("calver"), Series{VersionFormat: "calver"}.Format())
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package release
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/util/version"
+)
+
+func init() {
+	RegisterFormat(DefaultFormat, semverFormat{})
+	RegisterFormat("calver", calverFormat{})
+	RegisterFormat("dev-semi-semantic", devSemiSemanticFormat{})
+}
+
+// semverFormat is CockroachDB's own release naming scheme, e.g.
+// "23.1.13" or "23.1.0-rc.1". This is the format every release
+// series used before version formats became pluggable, and remains
+// the default for series that don't set `version_format`.
+type semverFormat struct{}
+
+func (semverFormat) Parse(s string) error {
+	_, err := version.Parse("v" + s)
+	return err
+}
+
+func (semverFormat) Compare(a, b string) int {
+	return version.MustParse("v" + a).Compare(version.MustParse("v" + b))
+}
+
+func (semverFormat) IsPreRelease(s string) bool {
+	return version.MustParse("v"+s).PreRelease() != ""
+}
+
+// calverFormat is a calendar-versioned scheme used by some cloud
+// builds, e.g. "2023.10.1" meaning "year 2023, month 10, build 1". It
+// has the same dotted-triple shape as semverFormat but no notion of
+// pre-release suffixes, since calver builds are never tagged as
+// alpha/beta/rc.
+type calverFormat struct{}
+
+var calverPattern = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)$`)
+
+func (calverFormat) Parse(s string) error {
+	if !calverPattern.MatchString(s) {
+		return fmt.Errorf("invalid calver version %q: expected YYYY.MM.N", s)
+	}
+	return nil
+}
+
+func (calverFormat) Compare(a, b string) int {
+	av, bv := calverPattern.FindStringSubmatch(a), calverPattern.FindStringSubmatch(b)
+	for i := 1; i <= 3; i++ {
+		an, _ := strconv.Atoi(av[i])
+		bn, _ := strconv.Atoi(bv[i])
+		if an != bn {
+			return an - bn
+		}
+	}
+	return 0
+}
+
+func (calverFormat) IsPreRelease(string) bool {
+	return false
+}
+
+// devSemiSemanticFormat covers dev/nightly builds that carry build
+// metadata after a "+", e.g. "23.1.0+build.5" or "23.1.0+dirty.5". The
+// metadata component is used to pick the most recent dev build within
+// a series; it is otherwise ignored for ordering purposes since it has
+// no defined relationship to semver precedence.
+type devSemiSemanticFormat struct{}
+
+var devSemiSemanticPattern = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)\+(?:build|dirty)\.(\d+)$`)
+
+func (devSemiSemanticFormat) Parse(s string) error {
+	if !devSemiSemanticPattern.MatchString(s) {
+		return fmt.Errorf("invalid dev-semi-semantic version %q: expected X.Y.Z+build.N", s)
+	}
+	return nil
+}
+
+func (devSemiSemanticFormat) Compare(a, b string) int {
+	am, bm := devSemiSemanticPattern.FindStringSubmatch(a), devSemiSemanticPattern.FindStringSubmatch(b)
+	for i := 1; i <= 4; i++ {
+		an, _ := strconv.Atoi(am[i])
+		bn, _ := strconv.Atoi(bm[i])
+		if an != bn {
+			return an - bn
+		}
+	}
+	return 0
+}
+
+func (devSemiSemanticFormat) IsPreRelease(s string) bool {
+	return strings.Contains(s, "+dirty.")
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package release contains information about CockroachDB releases
+// used by mixed-version logic tests and roachtests. The data itself
+// lives in cockroach_releases.yaml, which is generated by
+// `release update-releases-file` from the docs team's YAML and should
+// not be edited by hand.
+package release
+
+import (
+	_ "embed"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed cockroach_releases.yaml
+var releasesYAML []byte
+
+// Series describes what we know about a release series (e.g. "23.1")
+// for testing purposes.
+type Series struct {
+	// Latest is the most recent release in this series, without the
+	// leading "v" (e.g. "23.1.11"). It may itself be listed in
+	// Withdrawn.
+	Latest string `yaml:"latest"`
+	// Withdrawn lists every release in this series that has been
+	// withdrawn, without the leading "v".
+	Withdrawn []string `yaml:"withdrawn,omitempty"`
+	// Predecessor is the name of the release series immediately prior
+	// to this one (e.g. "22.2"), used to compute upgrade paths.
+	Predecessor string `yaml:"predecessor"`
+	// VersionFormat names the release Format (see RegisterFormat) that
+	// knows how to parse and order Latest/Withdrawn/Predecessor for
+	// this series. Empty means DefaultFormat, for back-compat with
+	// data generated before version formats became pluggable.
+	VersionFormat string `yaml:"version_format,omitempty"`
+}
+
+// Format returns the release.Format this series' versions should be
+// parsed and compared with, falling back to DefaultFormat if none was
+// recorded.
+func (s Series) Format() Format {
+	name := s.VersionFormat
+	if name == "" {
+		name = DefaultFormat
+	}
+	return MustGetFormat(name)
+}
+
+// AllSeries returns the release information embedded in
+// cockroach_releases.yaml, keyed by series name.
+func AllSeries() (map[string]Series, error) {
+	var data map[string]Series
+	if err := yaml.Unmarshal(releasesYAML, &data); err != nil {
+		return nil, fmt.Errorf("could not parse embedded release data: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,194 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package store
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fetch downloads, verifies, and extracts the cockroach binary for
+// the given version and platform into the store, and records it in
+// the index. Callers must hold the version's lock.
+func (s *Store) fetch(ctx context.Context, version string, plat platform) error {
+	dir := s.versionDir(version, plat)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+
+	tarballURL := fmt.Sprintf(binaryURLPattern, version, plat.releaseSuffix())
+	sha256URL := tarballURL + ".sha256"
+
+	wantSHA, err := downloadString(ctx, sha256URL)
+	if err != nil {
+		return fmt.Errorf("downloading checksum for v%s/%s: %w", version, plat, err)
+	}
+	fields := strings.Fields(wantSHA)
+	if len(fields) == 0 {
+		return fmt.Errorf("malformed checksum response for v%s/%s: %q", version, plat, wantSHA)
+	}
+	wantSHA = fields[0]
+
+	tarballPath := filepath.Join(dir, "cockroach.tgz")
+	if err := downloadResumable(ctx, tarballURL, tarballPath); err != nil {
+		return fmt.Errorf("downloading v%s/%s: %w", version, plat, err)
+	}
+	defer func() { _ = os.Remove(tarballPath) }()
+
+	gotSHA, err := sha256File(tarballPath)
+	if err != nil {
+		return err
+	}
+	if gotSHA != wantSHA {
+		return fmt.Errorf("checksum mismatch for v%s/%s: got %s, want %s", version, plat, gotSHA, wantSHA)
+	}
+
+	if err := extractBinary(tarballPath, s.binaryPath(version, plat)); err != nil {
+		return fmt.Errorf("extracting v%s/%s: %w", version, plat, err)
+	}
+
+	return s.recordEntry(version, plat, gotSHA)
+}
+
+// downloadResumable downloads url to dest, resuming from an existing
+// partial download (dest + ".partial") if one is present.
+func downloadResumable(ctx context.Context, url, dest string) error {
+	partial := dest + ".partial"
+
+	var startOffset int64
+	if fi, err := os.Stat(partial); err == nil {
+		startOffset = fi.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if startOffset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", startOffset))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if resp.StatusCode == http.StatusPartialContent {
+		flags |= os.O_APPEND
+	} else {
+		// Server doesn't support (or need) a range request; start over.
+		startOffset = 0
+		flags |= os.O_TRUNC
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("unexpected status %d downloading %s", resp.StatusCode, url)
+	}
+
+	f, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(partial, dest)
+}
+
+func downloadString(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d fetching %s", resp.StatusCode, url)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// extractBinary extracts the single `cockroach` executable out of a
+// release tarball, which normally contains a top-level
+// cockroach-vX.Y.Z.<platform>/ directory.
+func extractBinary(tarballPath, dest string) error {
+	f, err := os.Open(tarballPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("tarball %s does not contain a cockroach binary", tarballPath)
+		}
+		if err != nil {
+			return err
+		}
+		if filepath.Base(hdr.Name) != "cockroach" {
+			continue
+		}
+
+		out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			_ = out.Close()
+			return err
+		}
+		return out.Close()
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// indexFileName is the name of the metadata index kept at the root
+// of the cache directory.
+const indexFileName = "index.json"
+
+// indexEntry records what the store knows about a single cached
+// binary.
+type indexEntry struct {
+	Version  string    `json:"version"`
+	Platform string    `json:"platform"`
+	SHA256   string    `json:"sha256"`
+	ModTime  time.Time `json:"mtime"`
+}
+
+// indexKey returns the map key used to identify a cached binary by
+// version and platform.
+func indexKey(version string, plat platform) string {
+	return version + "@" + plat.String()
+}
+
+func (s *Store) indexPath() string {
+	return filepath.Join(s.baseDir, indexFileName)
+}
+
+// readIndex loads the metadata index, returning an empty index if the
+// file does not exist yet.
+func (s *Store) readIndex() (map[string]indexEntry, error) {
+	data, err := os.ReadFile(s.indexPath())
+	if os.IsNotExist(err) {
+		return map[string]indexEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var idx map[string]indexEntry
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// writeIndex persists the metadata index, creating the cache
+// directory if necessary.
+func (s *Store) writeIndex(idx map[string]indexEntry) error {
+	if err := os.MkdirAll(s.baseDir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so a crash mid-write can never
+	// leave a truncated index behind for a concurrent reader.
+	tmp, err := os.CreateTemp(s.baseDir, "index-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.indexPath())
+}
+
+func (s *Store) recordEntry(version string, plat platform, sha256 string) error {
+	unlock, err := s.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	idx, err := s.readIndex()
+	if err != nil {
+		return err
+	}
+	idx[indexKey(version, plat)] = indexEntry{
+		Version:  version,
+		Platform: plat.String(),
+		SHA256:   sha256,
+		ModTime:  time.Now(),
+	}
+	return s.writeIndex(idx)
+}
+
+func (s *Store) versionDir(version string, plat platform) string {
+	return filepath.Join(s.baseDir, plat.String(), version)
+}
+
+func (s *Store) binaryPath(version string, plat platform) string {
+	return filepath.Join(s.versionDir(version, plat), "cockroach")
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockRetryInterval is how long to wait between attempts to acquire a
+// lock that is currently held by another process.
+const lockRetryInterval = 200 * time.Millisecond
+
+// lockTimeout is how long to wait for a lock before giving up. A
+// single download of a cockroach binary, or a read-modify-write of the
+// index file, normally completes in well under a minute, so a lock
+// held longer than this most likely belongs to a process that died
+// without cleaning up.
+const lockTimeout = 5 * time.Minute
+
+// acquireLock acquires an exclusive, cross-process lock backed by the
+// sentinel file at lockPath, creating lockPath's parent directory if
+// necessary. It returns a function that releases the lock.
+//
+// The lock is implemented with a plain O_EXCL sentinel file rather
+// than flock(2), since the latter needs separate implementations per
+// platform; the contention window here (one download, or one index
+// update) is short enough that a poll-based lock is a fine trade-off.
+func acquireLock(lockPath string) (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", filepath.Dir(lockPath), err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("acquiring lock %s: %w", lockPath, err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out after %s waiting for lock %s held by another process", lockTimeout, lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// lock acquires an exclusive, cross-process lock on the cache entry
+// for the given version and platform, so that parallel test
+// invocations don't race to download and extract the same binary.
+func (s *Store) lock(version string, plat platform) (unlock func(), err error) {
+	return acquireLock(filepath.Join(s.versionDir(version, plat), ".lock"))
+}
+
+// lockIndex acquires an exclusive, cross-process lock on the shared
+// index file, so that concurrent Get/RemoveOlderThan/RemoveKeepLatest
+// calls for different versions or platforms don't race on the
+// index's read-modify-write cycle and silently drop each other's
+// entries.
+func (s *Store) lockIndex() (unlock func(), err error) {
+	return acquireLock(filepath.Join(s.baseDir, ".index.lock"))
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// platform identifies a target OS/architecture pair, e.g.
+// "linux/amd64".
+type platform struct {
+	os   string
+	arch string
+}
+
+// supportedPlatforms lists the platforms the store knows how to
+// download binaries for.
+var supportedPlatforms = map[platform]bool{
+	{"linux", "amd64"}:  true,
+	{"linux", "arm64"}:  true,
+	{"darwin", "amd64"}: true,
+	{"darwin", "arm64"}: true,
+}
+
+// String returns the platform in "os/arch" form.
+func (p platform) String() string {
+	return p.os + "/" + p.arch
+}
+
+// releaseSuffix returns the platform component used in release
+// tarball names, e.g. "linux-amd64".
+func (p platform) releaseSuffix() string {
+	return p.os + "-" + p.arch
+}
+
+// parsePlatform parses a platform string in "os/arch" form and
+// validates it against the set this package knows how to download
+// binaries for.
+func parsePlatform(s string) (platform, error) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return platform{}, fmt.Errorf("invalid platform %q: expected format os/arch", s)
+	}
+	p := platform{os: parts[0], arch: parts[1]}
+	if !supportedPlatforms[p] {
+		return platform{}, fmt.Errorf("unsupported platform %q", s)
+	}
+	return p, nil
+}
+
+// mustParsePlatform parses a platform string known to be valid, such
+// as one read back from the index file. It panics on invalid input,
+// which would indicate index file corruption.
+func mustParsePlatform(s string) platform {
+	p, err := parsePlatform(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// ParsePlatformList splits a comma-separated --platform flag value
+// into individual platform strings, trimming whitespace around each
+// one so that "linux/amd64, darwin/arm64" works as well as
+// "linux/amd64,darwin/arm64".
+func ParsePlatformList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
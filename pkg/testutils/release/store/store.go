@@ -0,0 +1,236 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package store downloads, verifies, and caches cockroach release
+// binaries described by pkg/testutils/release's embedded release
+// data, so that mixed-version roachtests and logic tests can run
+// against a curated corpus of historical binaries without hitting the
+// network on every invocation.
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils/release"
+	"github.com/cockroachdb/cockroach/pkg/util/version"
+)
+
+// binaryURLPattern is the public release URL pattern for cockroach
+// tarballs. %[1]s is the version (without the leading "v", which the
+// pattern supplies) and %[2]s is the platform in "os-arch" form (e.g.
+// "linux-amd64").
+const binaryURLPattern = "https://binaries.cockroachdb.com/cockroach-v%[1]s.%[2]s.tgz"
+
+// cacheDirName is the subdirectory created under the OS cache
+// directory to hold the store's contents.
+const cacheDirName = "cockroach-releases"
+
+// Store manages a local cache of cockroach binaries for one or more
+// platforms.
+type Store struct {
+	// baseDir is the root of the cache, normally
+	// filepath.Join(os.UserCacheDir(), cacheDirName).
+	baseDir string
+}
+
+// New returns a Store rooted at the OS-appropriate cache directory.
+func New() (*Store, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine user cache dir: %w", err)
+	}
+	return NewAt(filepath.Join(cacheDir, cacheDirName)), nil
+}
+
+// NewAt returns a Store rooted at the given directory. Tests that
+// want an isolated cache should use this constructor with a temp dir.
+func NewAt(baseDir string) *Store {
+	return &Store{baseDir: baseDir}
+}
+
+// Get returns the local path to the `cockroach` binary for the given
+// version and platform, downloading and caching it first if it is not
+// already present. version should not include the leading "v"
+// (e.g. "23.1.13"); platform is in "os/arch" form (e.g.
+// "linux/amd64").
+func Get(ctx context.Context, version, platform string) (string, error) {
+	s, err := New()
+	if err != nil {
+		return "", err
+	}
+	return s.Get(ctx, version, platform)
+}
+
+// Get is the instance form of the package-level Get.
+func (s *Store) Get(ctx context.Context, version, platform string) (string, error) {
+	plat, err := parsePlatform(platform)
+	if err != nil {
+		return "", err
+	}
+
+	binPath := s.binaryPath(version, plat)
+	unlock, err := s.lock(version, plat)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	if idx, err := s.readIndex(); err == nil {
+		if _, ok := idx[indexKey(version, plat)]; ok {
+			if _, statErr := os.Stat(binPath); statErr == nil {
+				return binPath, nil
+			}
+		}
+	}
+
+	if err := s.fetch(ctx, version, plat); err != nil {
+		return "", err
+	}
+	return binPath, nil
+}
+
+// List returns the versions currently cached for the given platform,
+// sorted as plain strings (callers that need version-aware ordering
+// should parse them via the release package's version registry).
+func (s *Store) List(platform string) ([]string, error) {
+	plat, err := parsePlatform(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for key, entry := range idx {
+		if entry.Platform == plat.String() {
+			versions = append(versions, entry.Version)
+			_ = key
+		}
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// RemoveOlderThan deletes every cached binary for a series older than
+// the given series (e.g. "23.1"), across all platforms.
+func (s *Store) RemoveOlderThan(series string) error {
+	target, err := version.Parse("v" + series + ".0")
+	if err != nil {
+		return fmt.Errorf("invalid series %q: %w", series, err)
+	}
+	return s.removeMatching(func(entry indexEntry) bool {
+		v, err := version.Parse("v" + seriesOf(entry.Version) + ".0")
+		return err == nil && !v.AtLeast(target)
+	})
+}
+
+// RemoveKeepLatest deletes cached binaries for each platform, keeping
+// only the n most recently added entries.
+func (s *Store) RemoveKeepLatest(n int) error {
+	idx, err := s.readIndex()
+	if err != nil {
+		return err
+	}
+
+	byPlatform := map[string][]indexEntry{}
+	for _, entry := range idx {
+		byPlatform[entry.Platform] = append(byPlatform[entry.Platform], entry)
+	}
+
+	keep := map[string]bool{}
+	for _, entries := range byPlatform {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].ModTime.After(entries[j].ModTime)
+		})
+		for i, entry := range entries {
+			if i < n {
+				keep[indexKey(entry.Version, mustParsePlatform(entry.Platform))] = true
+			}
+		}
+	}
+
+	// idx was read without the index lock, so entries added since then
+	// are not in it; they are newer than everything in it and are kept.
+	return s.removeMatching(func(entry indexEntry) bool {
+		key := indexKey(entry.Version, mustParsePlatform(entry.Platform))
+		_, known := idx[key]
+		return known && !keep[key]
+	})
+}
+
+// LatestVersions returns the latest release version for every known
+// series, keyed by series name. Series with no known release, or whose
+// latest release is listed among its Withdrawn releases, are omitted,
+// so callers don't need to cross-check against Withdrawn themselves.
+func LatestVersions() (map[string]string, error) {
+	allSeries, err := release.AllSeries()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := map[string]string{}
+	for name, series := range allSeries {
+		withdrawn := false
+		for _, w := range series.Withdrawn {
+			if w == series.Latest {
+				withdrawn = true
+			}
+		}
+		if series.Latest == "" || withdrawn {
+			continue
+		}
+		versions[name] = series.Latest
+	}
+	return versions, nil
+}
+
+func (s *Store) removeMatching(shouldRemove func(indexEntry) bool) error {
+	unlock, err := s.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	idx, err := s.readIndex()
+	if err != nil {
+		return err
+	}
+
+	for key, entry := range idx {
+		if !shouldRemove(entry) {
+			continue
+		}
+		plat := mustParsePlatform(entry.Platform)
+		if err := os.RemoveAll(s.versionDir(entry.Version, plat)); err != nil {
+			return fmt.Errorf("removing %s: %w", key, err)
+		}
+		delete(idx, key)
+	}
+
+	return s.writeIndex(idx)
+}
+
+// seriesOf returns the "X.Y" series prefix of a release version
+// string such as "23.1.13".
+func seriesOf(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
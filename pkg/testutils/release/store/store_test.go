@@ -0,0 +1,87 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlatform(t *testing.T) {
+	p, err := parsePlatform("linux/amd64")
+	require.NoError(t, err)
+	require.Equal(t, "linux/amd64", p.String())
+	require.Equal(t, "linux-amd64", p.releaseSuffix())
+
+	_, err = parsePlatform("plan9/amd64")
+	require.Error(t, err)
+
+	_, err = parsePlatform("linux")
+	require.Error(t, err)
+}
+
+func TestParsePlatformList(t *testing.T) {
+	require.Equal(t, []string{"linux/amd64", "darwin/arm64"}, ParsePlatformList("linux/amd64, darwin/arm64"))
+}
+
+func TestSeriesOf(t *testing.T) {
+	require.Equal(t, "23.1", seriesOf("23.1.13"))
+	require.Equal(t, "23.1", seriesOf("23.1"))
+}
+
+func TestLatestVersions(t *testing.T) {
+	versions, err := LatestVersions()
+	require.NoError(t, err)
+	require.Equal(t, "22.2.19", versions["22.2"])
+	require.Equal(t, "23.1.13", versions["23.1"])
+	require.Equal(t, "23.2.0", versions["23.2"])
+}
+
+func TestStoreRemoveOlderThan(t *testing.T) {
+	s := NewAt(t.TempDir())
+	plat := mustParsePlatform("linux/amd64")
+
+	idx := map[string]indexEntry{
+		indexKey("22.2.0", plat): {Version: "22.2.0", Platform: plat.String(), ModTime: time.Now()},
+		indexKey("23.1.0", plat): {Version: "23.1.0", Platform: plat.String(), ModTime: time.Now()},
+	}
+	require.NoError(t, s.writeIndex(idx))
+
+	require.NoError(t, s.RemoveOlderThan("23.1"))
+
+	remaining, err := s.readIndex()
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	require.Contains(t, remaining, indexKey("23.1.0", plat))
+}
+
+func TestStoreRemoveKeepLatest(t *testing.T) {
+	s := NewAt(t.TempDir())
+	plat := mustParsePlatform("linux/amd64")
+
+	now := time.Now()
+	idx := map[string]indexEntry{
+		indexKey("22.2.0", plat): {Version: "22.2.0", Platform: plat.String(), ModTime: now.Add(-2 * time.Hour)},
+		indexKey("23.1.0", plat): {Version: "23.1.0", Platform: plat.String(), ModTime: now.Add(-1 * time.Hour)},
+		indexKey("23.2.0", plat): {Version: "23.2.0", Platform: plat.String(), ModTime: now},
+	}
+	require.NoError(t, s.writeIndex(idx))
+
+	require.NoError(t, s.RemoveKeepLatest(2))
+
+	remaining, err := s.readIndex()
+	require.NoError(t, err)
+	require.Len(t, remaining, 2)
+	require.Contains(t, remaining, indexKey("23.1.0", plat))
+	require.Contains(t, remaining, indexKey("23.2.0", plat))
+}